@@ -0,0 +1,298 @@
+package embed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"hash/fnv"
+	"text/template"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Codec identifies a compression scheme supported by WriteCompressedVar.
+type Codec string
+
+// Supported codecs. CodecNone disables compression entirely.
+const (
+	CodecNone   Codec = "none"
+	CodecGzip   Codec = "gzip"
+	CodecZstd   Codec = "zstd"
+	CodecLZ4    Codec = "lz4"
+	CodecBrotli Codec = "brotli"
+)
+
+// WriteCompressedVar compresses data with codec and emits the decompression
+// code alongside a comment reporting the compressed vs. raw size, and
+// NameHash/NameSize constants holding the FNV-32 hash and byte count of the
+// raw (uncompressed) input, for reproducibility auditing. CodecNone with
+// lazy false is equivalent to WriteVar plus those constants; CodecNone emits
+// no size comment.
+//
+// If lazy is false, a package-level variable named name is populated from
+// the compressed payload by an init function. If lazy is true, name is
+// declared as an accessor func name() []byte instead of a variable. For a
+// compressing codec the accessor is sync.Once-guarded: decompression only
+// happens on first call, after which the compressed bytes are dropped so
+// they can be garbage-collected. For CodecNone the accessor simply returns
+// the bytes held in a package-level variable named nameData.
+func (cw *CodeWriter) WriteCompressedVar(name string, data []byte, codec Codec, lazy bool) error {
+	if codec == CodecNone {
+		var err error
+		if !lazy {
+			err = cw.WriteVar(name, data)
+		} else {
+			// Nothing to decompress, but still expose the func-based
+			// accessor so callers can pick -lazy independently of the codec.
+			fmt.Fprintf(&cw.body, "func %s() []byte { return %sData }\n\n", name, name)
+			err = cw.WriteVar(name+"Data", data)
+		}
+		if err != nil {
+			return err
+		}
+		cw.writeHashConsts(name, data)
+		return nil
+	}
+
+	c, ok := codecs[codec]
+	if !ok {
+		return fmt.Errorf("embed: unknown codec %q", codec)
+	}
+
+	compressed, err := c.compress(data)
+	if err != nil {
+		return fmt.Errorf("embed: compressing %s with %s: %v", name, codec, err)
+	}
+
+	for _, imp := range c.imports {
+		cw.imports[imp] = true
+	}
+
+	if lazy {
+		cw.imports["sync"] = true
+		if err := c.lazyPrologue.Execute(&cw.body, name); err != nil {
+			return err
+		}
+	} else {
+		if err := c.prologue.Execute(&cw.body, name); err != nil {
+			return err
+		}
+		fmt.Fprintf(&cw.body, "var %s []byte // set in init\n\n", name)
+	}
+	fmt.Fprintf(&cw.body, "// %s: %d bytes compressed (%s), %d bytes raw\n", name, len(compressed), codec, len(data))
+	cw.writeHashConsts(name, data)
+
+	return cw.WriteVar(name+"_"+string(codec), compressed)
+}
+
+// writeHashConsts emits NameHash/NameSize constants holding the FNV-32 hash
+// and byte count of the raw (pre-compression) contents of name, so that
+// downstream integrity checks don't need to decompress to verify data
+// wasn't corrupted or silently changed.
+func (cw *CodeWriter) writeHashConsts(name string, raw []byte) {
+	h := fnv.New32()
+	h.Write(raw)
+	fmt.Fprintf(&cw.body, "const %sHash = 0x%08x\n", name, h.Sum32())
+	fmt.Fprintf(&cw.body, "const %sSize = %d\n\n", name, len(raw))
+}
+
+type codec struct {
+	compress     func([]byte) ([]byte, error)
+	prologue     *template.Template
+	lazyPrologue *template.Template
+	imports      []string
+}
+
+var codecs = map[Codec]codec{
+	CodecGzip: {
+		compress: func(data []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := zw.Write(data); err != nil {
+				return nil, err
+			}
+			if err := zw.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+		imports: []string{"bytes", "compress/gzip", "io"},
+		prologue: template.Must(template.New("gzip").Parse(`
+func init() {
+	r, err := gzip.NewReader(bytes.NewReader({{.}}_gzip))
+	if err != nil {
+		panic(err)
+	}
+	defer r.Close()
+	{{.}}, err = io.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+}
+`)),
+		lazyPrologue: template.Must(template.New("gzip-lazy").Parse(`
+var (
+	{{.}}Once sync.Once
+	{{.}}Data []byte
+)
+
+func {{.}}() []byte {
+	{{.}}Once.Do(func() {
+		r, err := gzip.NewReader(bytes.NewReader({{.}}_gzip))
+		if err != nil {
+			panic(err)
+		}
+		defer r.Close()
+		{{.}}Data, err = io.ReadAll(r)
+		if err != nil {
+			panic(err)
+		}
+		{{.}}_gzip = nil
+	})
+	return {{.}}Data
+}
+`)),
+	},
+
+	CodecZstd: {
+		compress: func(data []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			zw, err := zstd.NewWriter(&buf, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+			if err != nil {
+				return nil, err
+			}
+			if _, err := zw.Write(data); err != nil {
+				return nil, err
+			}
+			if err := zw.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+		imports: []string{"bytes", "io", "github.com/klauspost/compress/zstd"},
+		prologue: template.Must(template.New("zstd").Parse(`
+func init() {
+	r, err := zstd.NewReader(bytes.NewReader({{.}}_zstd))
+	if err != nil {
+		panic(err)
+	}
+	defer r.Close()
+	{{.}}, err = io.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+}
+`)),
+		lazyPrologue: template.Must(template.New("zstd-lazy").Parse(`
+var (
+	{{.}}Once sync.Once
+	{{.}}Data []byte
+)
+
+func {{.}}() []byte {
+	{{.}}Once.Do(func() {
+		r, err := zstd.NewReader(bytes.NewReader({{.}}_zstd))
+		if err != nil {
+			panic(err)
+		}
+		defer r.Close()
+		{{.}}Data, err = io.ReadAll(r)
+		if err != nil {
+			panic(err)
+		}
+		{{.}}_zstd = nil
+	})
+	return {{.}}Data
+}
+`)),
+	},
+
+	CodecLZ4: {
+		compress: func(data []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			zw := lz4.NewWriter(&buf)
+			if _, err := zw.Write(data); err != nil {
+				return nil, err
+			}
+			if err := zw.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+		imports: []string{"bytes", "io", "github.com/pierrec/lz4/v4"},
+		prologue: template.Must(template.New("lz4").Parse(`
+func init() {
+	var err error
+	{{.}}, err = io.ReadAll(lz4.NewReader(bytes.NewReader({{.}}_lz4)))
+	if err != nil {
+		panic(err)
+	}
+}
+`)),
+		lazyPrologue: template.Must(template.New("lz4-lazy").Parse(`
+var (
+	{{.}}Once sync.Once
+	{{.}}Data []byte
+)
+
+func {{.}}() []byte {
+	{{.}}Once.Do(func() {
+		var err error
+		{{.}}Data, err = io.ReadAll(lz4.NewReader(bytes.NewReader({{.}}_lz4)))
+		if err != nil {
+			panic(err)
+		}
+		{{.}}_lz4 = nil
+	})
+	return {{.}}Data
+}
+`)),
+	},
+
+	CodecBrotli: {
+		compress: func(data []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			zw := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+			if _, err := zw.Write(data); err != nil {
+				return nil, err
+			}
+			if err := zw.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+		imports: []string{"bytes", "io", "github.com/andybalholm/brotli"},
+		prologue: template.Must(template.New("brotli").Parse(`
+func init() {
+	var err error
+	{{.}}, err = io.ReadAll(brotli.NewReader(bytes.NewReader({{.}}_brotli)))
+	if err != nil {
+		panic(err)
+	}
+}
+`)),
+		lazyPrologue: template.Must(template.New("brotli-lazy").Parse(`
+var (
+	{{.}}Once sync.Once
+	{{.}}Data []byte
+)
+
+func {{.}}() []byte {
+	{{.}}Once.Do(func() {
+		var err error
+		{{.}}Data, err = io.ReadAll(brotli.NewReader(bytes.NewReader({{.}}_brotli)))
+		if err != nil {
+			panic(err)
+		}
+		{{.}}_brotli = nil
+	})
+	return {{.}}Data
+}
+`)),
+	},
+}
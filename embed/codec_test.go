@@ -0,0 +1,85 @@
+package embed
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestWriteCompressedVarRoundTrip generates the decompression code
+// WriteCompressedVar emits for every codec, compiles it into a standalone
+// module and runs it, to make sure what decompresses at runtime matches
+// what was compressed in.
+func TestWriteCompressedVarRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 80))
+
+	for _, codec := range []Codec{CodecNone, CodecGzip, CodecZstd, CodecLZ4, CodecBrotli} {
+		codec := codec
+		t.Run(string(codec), func(t *testing.T) {
+			cw := NewCodeWriter(nil)
+			if err := cw.WriteCompressedVar("Data", data, codec, false); err != nil {
+				t.Fatalf("WriteCompressedVar: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := cw.WriteGoFile(&buf, "generated"); err != nil {
+				t.Fatalf("WriteGoFile: %v", err)
+			}
+
+			harness := fmt.Sprintf(`package generated
+
+import "testing"
+
+func TestRoundTrip(t *testing.T) {
+	want := %q
+	if string(Data) != want {
+		t.Fatalf("Data round-tripped wrong, got %%d bytes want %%d", len(Data), len(want))
+	}
+	if DataHash == 0 {
+		t.Fatal("DataHash is zero")
+	}
+	if int(DataSize) != len(want) {
+		t.Fatalf("DataSize = %%d, want %%d", DataSize, len(want))
+	}
+}
+`, string(data))
+
+			runGeneratedModule(t, buf.String(), harness)
+		})
+	}
+}
+
+// TestWriteCompressedVarLazyRoundTrip exercises the sync.Once-guarded lazy
+// accessor form (func name() []byte), which is the same for every codec, so
+// a single representative codec is enough here; the codec-specific
+// decompression is already covered by TestWriteCompressedVarRoundTrip.
+func TestWriteCompressedVarLazyRoundTrip(t *testing.T) {
+	data := []byte("lazily decompressed payload")
+
+	cw := NewCodeWriter(nil)
+	if err := cw.WriteCompressedVar("Data", data, CodecGzip, true); err != nil {
+		t.Fatalf("WriteCompressedVar: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := cw.WriteGoFile(&buf, "generated"); err != nil {
+		t.Fatalf("WriteGoFile: %v", err)
+	}
+
+	harness := fmt.Sprintf(`package generated
+
+import "testing"
+
+func TestRoundTrip(t *testing.T) {
+	want := %q
+	if got := string(Data()); got != want {
+		t.Fatalf("Data() = %%q, want %%q", got, want)
+	}
+	// A second call must return the same bytes from the cached copy.
+	if got := string(Data()); got != want {
+		t.Fatalf("second Data() = %%q, want %%q", got, want)
+	}
+}
+`, string(data))
+
+	runGeneratedModule(t, buf.String(), harness)
+}
@@ -0,0 +1,179 @@
+// Package embed implements the code generation used by the goembed command:
+// turning raw bytes into a Go source file that embeds them as a string
+// literal, optionally compressed with one of several codecs. It is factored
+// out of the goembed command so that other code generators can embed assets
+// programmatically instead of shelling out to goembed once per file.
+package embed
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"hash/fnv"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// CodeWriter accumulates generated Go declarations and renders them as a
+// complete source file, in the spirit of golang.org/x/text/internal/gen.CodeWriter.
+//
+// The zero value is not usable; use NewCodeWriter.
+type CodeWriter struct {
+	w       io.Writer
+	body    bytes.Buffer
+	imports map[string]bool
+}
+
+// NewCodeWriter returns a CodeWriter whose final output, once WriteGoFile is
+// called, defaults to w.
+func NewCodeWriter(w io.Writer) *CodeWriter {
+	return &CodeWriter{w: w, imports: map[string]bool{}}
+}
+
+// WriteVar emits a package-level declaration of the form
+// `var name = []byte("...")` holding the escaped contents of data.
+func (cw *CodeWriter) WriteVar(name string, data []byte) error {
+	fmt.Fprintf(&cw.body, "var %s = []byte(\"", name)
+	if _, err := io.Copy(&writer{w: &cw.body}, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	fmt.Fprint(&cw.body, "\")\n\n")
+	return nil
+}
+
+// WriteGzipVar gzip-compresses data and emits a package-level variable of
+// the same byte slice type, populated from the compressed payload by an
+// init function. It is a convenience wrapper around WriteCompressedVar.
+func (cw *CodeWriter) WriteGzipVar(name string, data []byte) error {
+	return cw.WriteCompressedVar(name, data, CodecGzip, false)
+}
+
+// WriteGoFile writes a complete, gofmt-formatted Go source file for package
+// pkg to w (or, if w is nil, to the writer passed to NewCodeWriter),
+// consisting of a generated-code header, the imports required by the
+// declarations written so far, those declarations, and a trailing FNV-32
+// hash + size comment of the formatted file contents, so that VerifyGoFile
+// can later detect drift. Output is deterministic: imports are sorted, so
+// the same inputs always produce the same bytes, hash comment included.
+func (cw *CodeWriter) WriteGoFile(w io.Writer, pkg string) error {
+	if w == nil {
+		w = cw.w
+	}
+	var out bytes.Buffer
+	fmt.Fprint(&out, "// Code generated by goembed. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+
+	if len(cw.imports) > 0 {
+		names := make([]string, 0, len(cw.imports))
+		for imp := range cw.imports {
+			names = append(names, imp)
+		}
+		sort.Strings(names)
+		fmt.Fprint(&out, "import (\n")
+		for _, imp := range names {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+		fmt.Fprint(&out, ")\n\n")
+	}
+
+	out.Write(cw.body.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		// Surface the unformatted source too so a caller can see what
+		// go/format choked on instead of just an error string.
+		return fmt.Errorf("formatting generated source: %v\n%s", err, out.Bytes())
+	}
+
+	h := fnv.New32()
+	h.Write(formatted)
+	trailer := fmt.Sprintf("// generated from %d bytes, fnv32 hash %x\n", len(formatted), h.Sum32())
+
+	if _, err := w.Write(formatted); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, trailer)
+	return err
+}
+
+var generatedCommentRe = regexp.MustCompile(`// generated from (\d+) bytes, fnv32 hash ([0-9a-f]+)\n\z`)
+
+// VerifyGoFile re-hashes a file previously produced by WriteGoFile and
+// reports whether its trailing size/hash comment still matches its
+// contents. Use it to detect a checked-in generated file that was edited
+// after it was generated; it does not regenerate the file from its inputs.
+func VerifyGoFile(data []byte) error {
+	loc := generatedCommentRe.FindSubmatchIndex(data)
+	if loc == nil {
+		return fmt.Errorf("embed: no trailing goembed hash comment found")
+	}
+	body := data[:loc[0]]
+
+	wantSize, err := strconv.Atoi(string(data[loc[2]:loc[3]]))
+	if err != nil {
+		return fmt.Errorf("embed: malformed size in hash comment: %v", err)
+	}
+	wantHash := string(data[loc[4]:loc[5]])
+
+	if len(body) != wantSize {
+		return fmt.Errorf("embed: size mismatch: comment claims %d bytes, file has %d", wantSize, len(body))
+	}
+
+	h := fnv.New32()
+	h.Write(body)
+	if gotHash := fmt.Sprintf("%x", h.Sum32()); gotHash != wantHash {
+		return fmt.Errorf("embed: hash mismatch: comment claims %s, computed %s", wantHash, gotHash)
+	}
+	return nil
+}
+
+// writer escapes bytes written to it as the contents of a Go string
+// literal and forwards the result to w.
+type writer struct {
+	w io.Writer
+}
+
+func (w *writer) Write(data []byte) (n int, err error) {
+	n = len(data)
+
+	for err == nil && len(data) > 0 {
+		// https://golang.org/ref/spec#String_literals: "Within the quotes, any
+		// character may appear except newline and unescaped double quote. The
+		// text between the quotes forms the value of the literal, with backslash
+		// escapes interpreted as they are in rune literals […]."
+		switch b := data[0]; b {
+		case '\\':
+			_, err = w.w.Write([]byte(`\\`))
+		case '"':
+			_, err = w.w.Write([]byte(`\"`))
+		case '\n':
+			_, err = w.w.Write([]byte(`\n`))
+
+		case '\x00':
+			// https://golang.org/ref/spec#Source_code_representation: "Implementation
+			// restriction: For compatibility with other tools, a compiler may
+			// disallow the NUL character (U+0000) in the source text."
+			_, err = w.w.Write([]byte(`\x00`))
+
+		default:
+			// https://golang.org/ref/spec#Source_code_representation: "Implementation
+			// restriction: […] A byte order mark may be disallowed anywhere else in
+			// the source."
+			const byteOrderMark = '\uFEFF'
+
+			if r, size := utf8.DecodeRune(data); r != utf8.RuneError && r != byteOrderMark {
+				_, err = w.w.Write(data[:size])
+				data = data[size:]
+				continue
+			}
+
+			_, err = fmt.Fprintf(w.w, `\x%02x`, b)
+		}
+		data = data[1:]
+	}
+
+	return n - len(data), err
+}
@@ -0,0 +1,81 @@
+package embed
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestWriteVarEscaping checks that WriteVar's escaper produces a Go string
+// literal that, unquoted, reproduces the original bytes exactly, including
+// for inputs that are awkward to embed: quotes, backslashes, control
+// characters, invalid UTF-8, and a byte order mark in the middle of the
+// data (see the byteOrderMark handling in writer.Write).
+func TestWriteVarEscaping(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte("")},
+		{"ascii", []byte("hello world")},
+		{"quotes and backslashes", []byte(`with "quotes", \backslashes\ and` + "\ttabs\nnewlines")},
+		{"control bytes", []byte{0, 1, 2, 0x1f, 0x7f}},
+		{"high bytes", []byte{0x80, 0xff, 0xfe}},
+		{"invalid utf8", []byte{'a', 0xc0, 0xaf, 'b'}},
+		{"bom in the middle", []byte("before\xef\xbb\xbfafter")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cw := NewCodeWriter(io.Discard)
+			if err := cw.WriteVar("x", tc.data); err != nil {
+				t.Fatalf("WriteVar: %v", err)
+			}
+
+			body := cw.body.String()
+			start := strings.Index(body, `"`)
+			end := strings.LastIndex(body, `"`)
+			if start == -1 || end <= start {
+				t.Fatalf("no string literal found in %q", body)
+			}
+			literal := body[start : end+1]
+
+			got, err := strconv.Unquote(literal)
+			if err != nil {
+				t.Fatalf("Unquote(%s): %v", literal, err)
+			}
+			if got != string(tc.data) {
+				t.Errorf("round-tripped data = %q, want %q", got, tc.data)
+			}
+		})
+	}
+}
+
+// TestVerifyGoFile checks that VerifyGoFile accepts a file WriteGoFile just
+// produced, rejects one whose contents were tampered with after the fact,
+// and rejects one with no trailing hash comment at all.
+func TestVerifyGoFile(t *testing.T) {
+	cw := NewCodeWriter(io.Discard)
+	if err := cw.WriteVar("x", []byte("hello")); err != nil {
+		t.Fatalf("WriteVar: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := cw.WriteGoFile(&buf, "generated"); err != nil {
+		t.Fatalf("WriteGoFile: %v", err)
+	}
+
+	if err := VerifyGoFile(buf.Bytes()); err != nil {
+		t.Errorf("VerifyGoFile on an untouched file: %v", err)
+	}
+
+	tampered := bytes.Replace(buf.Bytes(), []byte("hello"), []byte("jell0"), 1)
+	if err := VerifyGoFile(tampered); err == nil {
+		t.Error("VerifyGoFile on a tampered file: got nil error, want a mismatch")
+	}
+
+	if err := VerifyGoFile([]byte("package generated\n")); err == nil {
+		t.Error("VerifyGoFile on a file with no hash comment: got nil error, want one")
+	}
+}
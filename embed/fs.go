@@ -0,0 +1,301 @@
+package embed
+
+import (
+	"fmt"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// File is a single input to WriteFS: Name is the slash-separated path the
+// generated fs.FS will serve it under, Data is its raw (uncompressed)
+// content.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// WriteFS emits package-level declarations for each file in files,
+// compressed with codec (CodecNone disables compression), plus a generated
+// type named typeName implementing fs.FS, fs.ReadDirFS and fs.ReadFileFS
+// over all of them. varName is used as the prefix for the unexported
+// per-file byte slice variables.
+//
+// If lazy is true, each file's contents are decompressed at most once, on
+// first access, rather than at init time; see WriteCompressedVar.
+//
+// buildTime is reported as the ModTime of every generated fs.FileInfo; it is
+// typically the zero Time, unless a caller (e.g. -http mode) needs a
+// Last-Modified value to serve.
+//
+// Callers construct an instance with typeName{} and can then do e.g.
+// myassets.Open("templates/index.html").
+func (cw *CodeWriter) WriteFS(typeName, varName string, files []File, codec Codec, lazy bool, buildTime time.Time) error {
+	sorted := make([]File, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	cw.imports["io"] = true
+	cw.imports["io/fs"] = true
+	cw.imports["path"] = true
+	cw.imports["sort"] = true
+	cw.imports["strings"] = true
+	cw.imports["time"] = true
+
+	fmt.Fprintf(&cw.body, "var %sModTime = time.Unix(%d, 0)\n\n", varName, buildTime.Unix())
+
+	// The map's values are func() []byte rather than []byte so that eager
+	// and lazy (sync.Once-guarded) files share the same lookup code below;
+	// in the eager case the func is a trivial wrapper around the
+	// init-populated var.
+	varNames := make([]string, len(sorted))
+	for i, f := range sorted {
+		varNames[i] = fmt.Sprintf("%s%d", varName, i)
+		if err := cw.WriteCompressedVar(varNames[i], f.Data, codec, lazy); err != nil {
+			return fmt.Errorf("writing %s: %v", f.Name, err)
+		}
+	}
+
+	fmt.Fprintf(&cw.body, "var %s = map[string]func() []byte{\n", varName)
+	for i, f := range sorted {
+		if lazy {
+			fmt.Fprintf(&cw.body, "\t%q: %s,\n", f.Name, varNames[i])
+		} else {
+			fmt.Fprintf(&cw.body, "\t%q: func() []byte { return %s },\n", f.Name, varNames[i])
+		}
+	}
+	fmt.Fprint(&cw.body, "}\n\n")
+
+	// Raw sizes, known at generation time, so ReadDir can build FileInfo
+	// without invoking the (possibly lazy) accessor above just to measure
+	// len(data); doing so would force decompression of every listed file.
+	fmt.Fprintf(&cw.body, "var %sSize = map[string]int64{\n", varName)
+	for _, f := range sorted {
+		fmt.Fprintf(&cw.body, "\t%q: %d,\n", f.Name, len(f.Data))
+	}
+	fmt.Fprint(&cw.body, "}\n\n")
+
+	return fsPrologue.Execute(&cw.body, struct {
+		Type string
+		Var  string
+	}{typeName, varName})
+}
+
+var fsPrologue = template.Must(template.New("").Parse(`
+// {{.Type}}File also implements io.Seeker (beyond what fs.File requires) so
+// that http.FileServer, via WriteHTTP, can serve Range requests and sniff
+// the Content-Type of files whose extension mime.TypeByExtension doesn't
+// recognize.
+type {{.Type}}File struct {
+	name string
+	data []byte
+	off  int64
+}
+
+func (f *{{.Type}}File) Stat() (fs.FileInfo, error) { return {{.Type}}FileInfo{f.name, int64(len(f.data))}, nil }
+
+func (f *{{.Type}}File) Read(b []byte) (int, error) {
+	if f.off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.data[f.off:])
+	f.off += int64(n)
+	return n, nil
+}
+
+func (f *{{.Type}}File) Close() error { return nil }
+
+func (f *{{.Type}}File) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = f.off + offset
+	case io.SeekEnd:
+		abs = int64(len(f.data)) + offset
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if abs < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	f.off = abs
+	return abs, nil
+}
+
+type {{.Type}}FileInfo struct {
+	name string
+	size int64
+}
+
+func (i {{.Type}}FileInfo) Name() string       { return path.Base(i.name) }
+func (i {{.Type}}FileInfo) Size() int64        { return i.size }
+func (i {{.Type}}FileInfo) Mode() fs.FileMode  { return 0444 }
+func (i {{.Type}}FileInfo) ModTime() time.Time { return {{.Var}}ModTime }
+func (i {{.Type}}FileInfo) IsDir() bool        { return false }
+func (i {{.Type}}FileInfo) Sys() interface{}   { return nil }
+
+// {{.Type}} implements fs.FS, fs.ReadDirFS and fs.ReadFileFS over the
+// embedded files in {{.Var}}.
+type {{.Type}} struct{}
+
+func (t {{.Type}}) Open(name string) (fs.File, error) {
+	if getData, ok := {{.Var}}[name]; ok {
+		return &{{.Type}}File{name: name, data: getData()}, nil
+	}
+	if name == "." || t.isDir(name) {
+		return &{{.Type}}Dir{fs: t, name: name}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// isDir reports whether name is a directory implied by some embedded file's
+// path, e.g. "templates" for an embedded "templates/index.html".
+func (t {{.Type}}) isDir(name string) bool {
+	prefix := name + "/"
+	for file := range {{.Var}} {
+		if strings.HasPrefix(file, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// {{.Type}}Dir is the fs.ReadDirFile returned by Open for "." and any other
+// directory implied by the embedded files, since there is no backing
+// on-disk directory to open. It tracks a read position so that, per the
+// fs.ReadDirFile contract, repeated ReadDir calls page through the listing
+// instead of each returning it from the start.
+type {{.Type}}Dir struct {
+	fs      {{.Type}}
+	name    string
+	entries []fs.DirEntry // lazily populated by the first ReadDir call
+	loaded  bool
+	offset  int
+}
+
+func (d *{{.Type}}Dir) Stat() (fs.FileInfo, error) { return {{.Type}}DirInfo{d.name}, nil }
+
+func (d *{{.Type}}Dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+func (d *{{.Type}}Dir) Close() error { return nil }
+
+func (d *{{.Type}}Dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.loaded {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.loaded = true
+	}
+
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
+
+// {{.Type}}DirInfo is the fs.FileInfo for a {{.Type}}Dir.
+type {{.Type}}DirInfo struct{ name string }
+
+func (i {{.Type}}DirInfo) Name() string {
+	if i.name == "." {
+		return "."
+	}
+	return path.Base(i.name)
+}
+func (i {{.Type}}DirInfo) Size() int64        { return 0 }
+func (i {{.Type}}DirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (i {{.Type}}DirInfo) ModTime() time.Time { return {{.Var}}ModTime }
+func (i {{.Type}}DirInfo) IsDir() bool        { return true }
+func (i {{.Type}}DirInfo) Sys() interface{}   { return nil }
+
+func (t {{.Type}}) ReadFile(name string) ([]byte, error) {
+	getData, ok := {{.Var}}[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+	// ReadFile must return a copy: getData returns the package-level backing
+	// slice, and fs.ReadFileFS callers are allowed to mutate what they get
+	// back without affecting later reads.
+	data := getData()
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	return cp, nil
+}
+
+func (t {{.Type}}) ReadDir(name string) ([]fs.DirEntry, error) {
+	prefix := name
+	if prefix != "." {
+		prefix += "/"
+	} else {
+		prefix = ""
+	}
+
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	for file := range {{.Var}} {
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(file, prefix)
+		if rest == "" {
+			continue
+		}
+		segment := rest
+		isDir := false
+		if idx := strings.IndexByte(rest, '/'); idx >= 0 {
+			segment = rest[:idx]
+			isDir = true
+		}
+		if seen[segment] {
+			continue
+		}
+		seen[segment] = true
+		if isDir {
+			entries = append(entries, {{.Type}}DirEntry{name: segment, isDir: true})
+		} else {
+			// Use the precomputed size instead of calling {{.Var}}[file](),
+			// which would force decompression of every listed file just to
+			// measure it in -lazy mode.
+			entries = append(entries, {{.Type}}DirEntry{name: segment, fileInfo: {{.Type}}FileInfo{file, {{.Var}}Size[file]}})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+type {{.Type}}DirEntry struct {
+	name     string
+	isDir    bool
+	fileInfo {{.Type}}FileInfo
+}
+
+func (e {{.Type}}DirEntry) Name() string      { return e.name }
+func (e {{.Type}}DirEntry) IsDir() bool       { return e.isDir }
+func (e {{.Type}}DirEntry) Type() fs.FileMode { return e.info().Mode().Type() }
+
+func (e {{.Type}}DirEntry) Info() (fs.FileInfo, error) { return e.info(), nil }
+
+// info returns the fs.FileInfo for the entry, which never errors because
+// {{.Type}}DirEntry is always constructed from data already in memory.
+func (e {{.Type}}DirEntry) info() fs.FileInfo {
+	if e.isDir {
+		return {{.Type}}DirInfo{e.name}
+	}
+	return e.fileInfo
+}
+`))
@@ -0,0 +1,59 @@
+package embed
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestWriteFSConformance generates a small fs.FS implementation with
+// WriteFS, compiles it as a standalone package, and runs it through
+// testing/fstest.TestFS plus fs.WalkDir to make sure the emitted Open,
+// ReadDir and ReadFile methods satisfy the stdlib's own fs.FS contract —
+// including for the root directory ".".
+func TestWriteFSConformance(t *testing.T) {
+	cw := NewCodeWriter(nil)
+	files := []File{
+		{Name: "style.css", Data: []byte("body{}")},
+		{Name: "templates/index.html", Data: []byte("<html></html>")},
+	}
+	if err := cw.WriteFS("Assets", "asset", files, CodecNone, false, time.Time{}); err != nil {
+		t.Fatalf("WriteFS: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cw.WriteGoFile(&buf, "generated"); err != nil {
+		t.Fatalf("WriteGoFile: %v", err)
+	}
+
+	harness := `package generated
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestConformance(t *testing.T) {
+	if err := fstest.TestFS(Assets{}, "style.css", "templates/index.html"); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	if err := fs.WalkDir(Assets{}, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count == 0 {
+		t.Fatal("WalkDir visited no entries")
+	}
+}
+`
+
+	runGeneratedModule(t, buf.String(), harness)
+}
@@ -0,0 +1,55 @@
+package embed
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runGeneratedModule writes generated (a complete Go source file produced
+// by WriteGoFile) and test (a _test.go file for the same package) into a
+// standalone module, then runs `go test` there. Code generators can only be
+// verified by actually compiling and running what they emit, which a plain
+// in-process unit test can't do for a full Go source file.
+//
+// It requires a `go` toolchain on PATH and the module cache already
+// populated with this repo's dependencies (as building the repo itself
+// does); it does not need network access.
+func runGeneratedModule(t *testing.T, generated, test string) {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skipf("go toolchain not found: %v", err)
+	}
+
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	write("generated.go", generated)
+	write("generated_test.go", test)
+
+	// Reuse this repo's own go.mod/go.sum so the generated module resolves
+	// the same codec dependency versions from the local module cache,
+	// renamed so it doesn't collide with the real module path.
+	modFile, err := os.ReadFile("../go.mod")
+	if err != nil {
+		t.Fatalf("reading go.mod: %v", err)
+	}
+	write("go.mod", strings.Replace(string(modFile), "module github.com/stapelberg/goembed", "module generatedtest", 1))
+	if sum, err := os.ReadFile("../go.sum"); err == nil {
+		write("go.sum", string(sum))
+	}
+
+	cmd := exec.Command(goBin, "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test on generated module failed: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,67 @@
+package embed
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"text/template"
+)
+
+// WriteHTTP emits a Handler(prefix string) http.Handler and a
+// FileSystem() http.FileSystem method on typeName, on top of the fs.FS
+// produced by a prior call to WriteFS with the same typeName and varName.
+// Content-Type sniffing and Last-Modified come for free from
+// http.FileServer(http.FS(...)); WriteHTTP additionally precomputes an ETag
+// per file from the FNV-32 hash of its raw contents and sets it on every
+// response.
+func (cw *CodeWriter) WriteHTTP(typeName, varName string, files []File) error {
+	cw.imports["net/http"] = true
+	cw.imports["strings"] = true
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+
+	etags := make(map[string][]byte, len(files))
+	for _, f := range files {
+		etags[f.Name] = f.Data
+	}
+
+	fmt.Fprintf(&cw.body, "var %sEtags = map[string]string{\n", varName)
+	for _, name := range names {
+		// Use the same FNV-32 variant as writeHashConsts/WriteGoFile
+		// elsewhere in this package, so a file's ETag and its NameHash
+		// constant are directly comparable.
+		h := fnv.New32()
+		h.Write(etags[name])
+		fmt.Fprintf(&cw.body, "\t%q: %q,\n", name, fmt.Sprintf(`"%x"`, h.Sum32()))
+	}
+	fmt.Fprint(&cw.body, "}\n\n")
+
+	return httpPrologue.Execute(&cw.body, struct {
+		Type string
+		Var  string
+	}{typeName, varName})
+}
+
+var httpPrologue = template.Must(template.New("").Parse(`
+// Handler returns an http.Handler serving the embedded files rooted at
+// prefix, with Content-Type sniffing, Last-Modified and ETag headers set.
+func (t {{.Type}}) Handler(prefix string) http.Handler {
+	fileServer := http.FileServer(t.FileSystem())
+	stripped := http.StripPrefix(prefix, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag, ok := {{.Var}}Etags[strings.TrimPrefix(r.URL.Path, "/")]; ok {
+			w.Header().Set("ETag", etag)
+		}
+		fileServer.ServeHTTP(w, r)
+	}))
+	return stripped
+}
+
+// FileSystem returns an http.FileSystem view of the embedded files.
+func (t {{.Type}}) FileSystem() http.FileSystem {
+	return http.FS(t)
+}
+`))
@@ -0,0 +1,100 @@
+package embed
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestWriteHTTPServesSniffedAndRangeRequests generates a small fs.FS plus
+// Handler with WriteFS/WriteHTTP, compiles it as a standalone package, and
+// drives the generated Handler with a real httptest.Server so that
+// net/http's own content-type sniffing and Range-request handling run
+// against it. Both of those require the generated {{.Type}}File to
+// implement io.Seeker: without it, http.FileServer either 500s trying to
+// sniff an extensionless file's Content-Type or returns 416 instead of 206
+// for a Range request.
+func TestWriteHTTPServesSniffedAndRangeRequests(t *testing.T) {
+	cw := NewCodeWriter(nil)
+	files := []File{
+		// No extension, so http.FileServer must Read (via Seek back to the
+		// start) to sniff the Content-Type instead of using
+		// mime.TypeByExtension.
+		{Name: "README", Data: []byte("# hello\n\nthis is a readme file, long enough to sniff as text.\n")},
+	}
+	if err := cw.WriteFS("Assets", "asset", files, CodecNone, false, time.Time{}); err != nil {
+		t.Fatalf("WriteFS: %v", err)
+	}
+	if err := cw.WriteHTTP("Assets", "asset", files); err != nil {
+		t.Fatalf("WriteHTTP: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := cw.WriteGoFile(&buf, "generated"); err != nil {
+		t.Fatalf("WriteGoFile: %v", err)
+	}
+
+	harness := fmt.Sprintf(`package generated
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	want := %q
+
+	srv := httptest.NewServer(Assets{}.Handler("/"))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/README")
+	if err != nil {
+		t.Fatalf("GET /README: %%v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("reading body: %%v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /README: status = %%d, want %%d (body %%q)", resp.StatusCode, http.StatusOK, body)
+	}
+	if string(body) != want {
+		t.Fatalf("GET /README: body = %%q, want %%q", body, want)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("GET /README: Content-Type = %%q, want a sniffed text/plain", ct)
+	}
+	if resp.Header.Get("ETag") == "" {
+		t.Fatal("GET /README: no ETag header set")
+	}
+
+	req, err := http.NewRequest("GET", srv.URL+"/README", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %%v", err)
+	}
+	req.Header.Set("Range", "bytes=2-6")
+	rresp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("ranged GET /README: %%v", err)
+	}
+	rbody, err := io.ReadAll(rresp.Body)
+	rresp.Body.Close()
+	if err != nil {
+		t.Fatalf("reading ranged body: %%v", err)
+	}
+	if rresp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("ranged GET /README: status = %%d, want %%d (body %%q)", rresp.StatusCode, http.StatusPartialContent, rbody)
+	}
+	if want := want[2:7]; string(rbody) != want {
+		t.Fatalf("ranged GET /README: body = %%q, want %%q", rbody, want)
+	}
+}
+`, string(files[0].Data))
+
+	runGeneratedModule(t, buf.String(), harness)
+}
@@ -1,125 +1,138 @@
-// goembed generates a Go source file from an input file.
+// goembed generates a Go source file from an input file, or — in -dir mode —
+// from a whole directory tree.
 package main
 
 import (
-	"bytes"
-	"compress/gzip"
 	"flag"
-	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"text/template"
-	"unicode/utf8"
+	"path/filepath"
+	"time"
+
+	"github.com/stapelberg/goembed/embed"
 )
 
 var (
-	packageFlag = flag.String("package", "", "Go package name")
-	varFlag     = flag.String("var", "", "Go var name")
-	gzipFlag    = flag.Bool("gzip", false, "Whether to gzip contents")
+	packageFlag   = flag.String("package", "", "Go package name")
+	varFlag       = flag.String("var", "", "Go var name (single-file mode)")
+	compressFlag  = flag.String("compress", "none", "Compression codec: none, gzip, zstd, lz4 or brotli")
+	lazyFlag      = flag.Bool("lazy", false, "Decompress lazily on first access instead of in init()")
+	dirFlag       = flag.String("dir", "", "Directory to embed recursively as an fs.FS (multi-file mode)")
+	typeFlag      = flag.String("type", "Assets", "Go type name for the generated fs.FS (multi-file mode)")
+	httpFlag      = flag.Bool("http", false, "Also emit http.Handler/http.FileSystem bindings (multi-file mode)")
+	buildTimeFlag = flag.Int64("buildtime", 0, "Unix timestamp reported as Last-Modified in -http mode")
+	verifyFlag    = flag.Bool("verify", false, "Re-hash a previously generated file (read from stdin or the first argument) instead of generating one")
 )
 
 func main() {
 	flag.Parse()
 
-	raw, err := ioutil.ReadAll(os.Stdin)
-	if err != nil {
-		log.Fatalf("Reading stdin: %v", err)
+	if *verifyFlag {
+		if err := verify(); err != nil {
+			log.Fatalf("Verification failed: %v", err)
+		}
+		return
+	}
+
+	codec := embed.Codec(*compressFlag)
+	if codec != embed.CodecNone && codec != embed.CodecGzip && codec != embed.CodecZstd && codec != embed.CodecLZ4 && codec != embed.CodecBrotli {
+		log.Fatalf("Unknown -compress value %q", *compressFlag)
 	}
 
-	fmt.Printf("package %s\n\n", *packageFlag)
+	cw := embed.NewCodeWriter(os.Stdout)
 
-	// Generate []byte(<big string constant>) instead of []byte{<list of byte values>}.
-	// The latter causes a memory explosion in the compiler (60 MB of input chews over 9 GB RAM).
-	// Doing a string conversion avoids some of that, but incurs a slight startup cost.
-	if !*gzipFlag {
-		fmt.Printf(`var %s = []byte("`, *varFlag)
+	var err error
+	if *dirFlag != "" || flag.NArg() > 0 {
+		err = writeFS(cw, codec, *lazyFlag)
 	} else {
-		var buf bytes.Buffer
-		gzw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
-		if _, err := gzw.Write(raw); err != nil {
-			log.Fatal(err)
-		}
-		if err := gzw.Close(); err != nil {
-			log.Fatal(err)
-		}
-		gz := buf.Bytes()
-
-		if err := gzipPrologue.Execute(os.Stdout, *varFlag); err != nil {
-			log.Fatal(err)
-		}
-		fmt.Printf("var %s []byte // set in init\n\n", *varFlag)
-		fmt.Printf(`var %s_gzip = []byte("`, *varFlag)
-		raw = gz
+		err = writeSingle(cw, codec, *lazyFlag)
+	}
+	if err != nil {
+		log.Fatalf("Generating %s: %v", *varFlag, err)
 	}
 
-	io.Copy(&writer{w: os.Stdout}, bytes.NewReader(raw))
-	fmt.Println(`")`)
+	if err := cw.WriteGoFile(os.Stdout, *packageFlag); err != nil {
+		log.Fatalf("Writing generated file: %v", err)
+	}
 }
 
-type writer struct {
-	w io.Writer
+// verify implements -verify: it reads a previously generated file (from the
+// first non-flag argument, or stdin if none was given) and re-hashes it to
+// detect drift from its trailing goembed hash comment.
+func verify() error {
+	var (
+		data []byte
+		err  error
+	)
+	if flag.NArg() > 0 {
+		data, err = ioutil.ReadFile(flag.Arg(0))
+	} else {
+		data, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+	if err := embed.VerifyGoFile(data); err != nil {
+		return err
+	}
+	log.Print("OK")
+	return nil
 }
 
-func (w *writer) Write(data []byte) (n int, err error) {
-	n = len(data)
-
-	for err == nil && len(data) > 0 {
-		// https://golang.org/ref/spec#String_literals: "Within the quotes, any
-		// character may appear except newline and unescaped double quote. The
-		// text between the quotes forms the value of the literal, with backslash
-		// escapes interpreted as they are in rune literals […]."
-		switch b := data[0]; b {
-		case '\\':
-			_, err = w.w.Write([]byte(`\\`))
-		case '"':
-			_, err = w.w.Write([]byte(`\"`))
-		case '\n':
-			_, err = w.w.Write([]byte(`\n`))
-
-		case '\x00':
-			// https://golang.org/ref/spec#Source_code_representation: "Implementation
-			// restriction: For compatibility with other tools, a compiler may
-			// disallow the NUL character (U+0000) in the source text."
-			_, err = w.w.Write([]byte(`\x00`))
-
-		default:
-			// https://golang.org/ref/spec#Source_code_representation: "Implementation
-			// restriction: […] A byte order mark may be disallowed anywhere else in
-			// the source."
-			const byteOrderMark = '\uFEFF'
+// writeSingle implements the original single-file mode: the file contents
+// are read from stdin and embedded as *varFlag.
+func writeSingle(cw *embed.CodeWriter, codec embed.Codec, lazy bool) error {
+	raw, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	return cw.WriteCompressedVar(*varFlag, raw, codec, lazy)
+}
 
-			if r, size := utf8.DecodeRune(data); r != utf8.RuneError && r != byteOrderMark {
-				_, err = w.w.Write(data[:size])
-				data = data[size:]
-				continue
+// writeFS implements multi-file mode: either *dirFlag is walked recursively,
+// or the remaining non-flag arguments are taken as an explicit file list.
+// The result is a generated fs.FS named *typeFlag.
+func writeFS(cw *embed.CodeWriter, codec embed.Codec, lazy bool) error {
+	var names []string
+	if *dirFlag != "" {
+		if err := filepath.Walk(*dirFlag, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
 			}
-
-			_, err = fmt.Fprintf(w.w, `\x%02x`, b)
+			if info.IsDir() {
+				return nil
+			}
+			names = append(names, p)
+			return nil
+		}); err != nil {
+			return err
 		}
-		data = data[1:]
+	} else {
+		names = flag.Args()
 	}
 
-	return n - len(data), err
-}
-
-var gzipPrologue = template.Must(template.New("").Parse(`
-import (
-	"bytes"
-	"compress/gzip"
-	"io/ioutil"
-)
+	files := make([]embed.File, 0, len(names))
+	for _, name := range names {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel := name
+		if *dirFlag != "" {
+			if rel, err = filepath.Rel(*dirFlag, name); err != nil {
+				return err
+			}
+		}
+		files = append(files, embed.File{Name: filepath.ToSlash(rel), Data: data})
+	}
 
-func init() {
-	r, err := gzip.NewReader(bytes.NewReader({{.}}_gzip))
-	if err != nil {
-		panic(err)
+	varName := "_" + *typeFlag + "Files"
+	if err := cw.WriteFS(*typeFlag, varName, files, codec, lazy, time.Unix(*buildTimeFlag, 0)); err != nil {
+		return err
 	}
-	defer r.Close()
-	{{.}}, err = ioutil.ReadAll(r)
-	if err != nil {
-		panic(err)
+	if *httpFlag {
+		return cw.WriteHTTP(*typeFlag, varName, files)
 	}
+	return nil
 }
-`))